@@ -0,0 +1,208 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"gopkg.in/src-d/go-errors.v0"
+)
+
+// AuthMode selects how Server.Serve authenticates incoming gRPC
+// connections.
+type AuthMode string
+
+const (
+	// AuthNone serves plaintext gRPC with no transport security. This is
+	// the default.
+	AuthNone AuthMode = "none"
+	// AuthTLS serves TLS, authenticating the server to clients but not
+	// clients to the server.
+	AuthTLS AuthMode = "tls"
+	// AuthMTLS additionally requires and verifies a client certificate
+	// against ServerOptions.ClientCAFile.
+	AuthMTLS AuthMode = "mtls"
+	// AuthIdentity authenticates both ends by libtrust-style public-key
+	// fingerprint rather than a certificate authority, pinned in
+	// ServerOptions.TrustDir.
+	AuthIdentity AuthMode = "identity"
+)
+
+// ErrAuth is returned when a connection fails authentication.
+var ErrAuth = errors.NewKind("unauthorized connection")
+
+// ServerOptions configures the transport security and client authentication
+// Server.Serve applies to the gRPC listener.
+type ServerOptions struct {
+	// AuthMode selects the authentication scheme. Defaults to AuthNone.
+	AuthMode AuthMode
+
+	// CertFile and KeyFile are the server's TLS certificate and private
+	// key, required for AuthTLS and AuthMTLS.
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile is the CA bundle client certificates are verified
+	// against, required for AuthMTLS.
+	ClientCAFile string
+
+	// IdentityKeyPath is where the server's identity key is loaded from,
+	// or created if absent, required for AuthIdentity.
+	IdentityKeyPath string
+	// TrustDir holds one file per pinned client public-key fingerprint
+	// authorized to connect, required for AuthIdentity.
+	TrustDir string
+}
+
+type callerIdentityKey struct{}
+
+// CallerIdentity returns the authenticated identity of the peer that made
+// the call carried by ctx, or "" if the connection wasn't authenticated.
+func CallerIdentity(ctx context.Context) string {
+	id, _ := ctx.Value(callerIdentityKey{}).(string)
+	return id
+}
+
+// Configure applies opts to s, building the TLSConfig and identity
+// material s.Serve needs to enforce opts.AuthMode.
+func (s *Server) Configure(opts ServerOptions) error {
+	s.AuthMode = opts.AuthMode
+	if s.AuthMode == "" {
+		s.AuthMode = AuthNone
+	}
+
+	switch s.AuthMode {
+	case AuthNone:
+		return nil
+
+	case AuthTLS, AuthMTLS:
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return ErrAuth.Wrap(err)
+		}
+
+		cfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+		if s.AuthMode == AuthMTLS {
+			pool := x509.NewCertPool()
+			pem, err := ioutil.ReadFile(opts.ClientCAFile)
+			if err != nil {
+				return ErrAuth.Wrap(err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return ErrAuth.New()
+			}
+
+			cfg.ClientCAs = pool
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+
+		s.TLSConfig = cfg
+		return nil
+
+	case AuthIdentity:
+		key, err := LoadOrCreateIdentity(opts.IdentityKeyPath)
+		if err != nil {
+			return err
+		}
+
+		cert, err := key.selfSignedCert()
+		if err != nil {
+			return err
+		}
+
+		s.identity = key
+		s.trustDir = opts.TrustDir
+		s.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAnyClientCert,
+		}
+		return nil
+
+	default:
+		return ErrAuth.New()
+	}
+}
+
+// grpcServerOptions builds the grpc.ServerOption set Serve uses to enforce
+// s.TLSConfig and s.AuthMode.
+func (s *Server) grpcServerOptions() []grpc.ServerOption {
+	var opts []grpc.ServerOption
+
+	if s.TLSConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.TLSConfig)))
+	}
+
+	opts = append(opts,
+		grpc.UnaryInterceptor(s.authUnaryInterceptor),
+		grpc.StreamInterceptor(s.authStreamInterceptor),
+	)
+
+	return opts
+}
+
+func (s *Server) authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+}
+
+// authServerStream overrides Context so handlers observe the identity
+// authenticate annotated onto it.
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context { return s.ctx }
+
+// authenticate verifies the peer carried by ctx against s.AuthMode and, on
+// success, returns ctx annotated with the caller's identity.
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+	switch s.AuthMode {
+	case "", AuthNone, AuthTLS:
+		return ctx, nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, ErrAuth.New()
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return nil, ErrAuth.New()
+	}
+	cert := info.State.PeerCertificates[0]
+
+	switch s.AuthMode {
+	case AuthMTLS:
+		return context.WithValue(ctx, callerIdentityKey{}, cert.Subject.CommonName), nil
+
+	case AuthIdentity:
+		fp := fingerprintPublicKey(cert.RawSubjectPublicKeyInfo)
+		if !isTrusted(s.trustDir, fp) {
+			return nil, ErrAuth.New()
+		}
+
+		return context.WithValue(ctx, callerIdentityKey{}, fp), nil
+
+	default:
+		return nil, ErrAuth.New()
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestAuthenticateNoneIsPassthrough(t *testing.T) {
+	s := &Server{AuthMode: AuthNone}
+
+	ctx, err := s.authenticate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if CallerIdentity(ctx) != "" {
+		t.Fatal("expected no caller identity in AuthNone mode")
+	}
+}
+
+func TestAuthenticateMTLSRequiresPeer(t *testing.T) {
+	s := &Server{AuthMode: AuthMTLS}
+
+	if _, err := s.authenticate(context.Background()); err == nil {
+		t.Fatal("expected an error when the context carries no peer")
+	}
+}
+
+func TestAuthenticateIdentityRequiresPeer(t *testing.T) {
+	s := &Server{AuthMode: AuthIdentity}
+
+	if _, err := s.authenticate(context.Background()); err == nil {
+		t.Fatal("expected an error when the context carries no peer")
+	}
+}
+
+func TestIdentityFingerprintTrust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bblfsh-identity-test")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := LoadOrCreateIdentity(filepath.Join(dir, "identity.pem"))
+	if err != nil {
+		t.Fatalf("LoadOrCreateIdentity: %s", err)
+	}
+
+	fp, err := key.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint: %s", err)
+	}
+
+	if isTrusted(dir, fp) {
+		t.Fatal("expected the fingerprint to be untrusted before pinning")
+	}
+
+	pinned := strings.Replace(fp, ":", "-", -1)
+	if err := ioutil.WriteFile(filepath.Join(dir, pinned), nil, 0600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	if !isTrusted(dir, fp) {
+		t.Fatal("expected the fingerprint to be trusted once pinned")
+	}
+}
+
+func TestLoadOrCreateIdentityIsStable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bblfsh-identity-test")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "identity.pem")
+
+	first, err := LoadOrCreateIdentity(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateIdentity: %s", err)
+	}
+	firstFP, err := first.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint: %s", err)
+	}
+
+	second, err := LoadOrCreateIdentity(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateIdentity (reload): %s", err)
+	}
+	secondFP, err := second.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint (reload): %s", err)
+	}
+
+	if firstFP != secondFP {
+		t.Fatalf("expected the same identity to be reloaded, got %q then %q", firstFP, secondFP)
+	}
+}
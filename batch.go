@@ -0,0 +1,66 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/bblfsh/sdk/protocol"
+)
+
+// DefaultMaxInFlightParses is the default bound on how many requests of a
+// batch are processed concurrently, used when Server.MaxInFlightParses is
+// unset.
+const DefaultMaxInFlightParses = 64
+
+// ParseUASTBatchRequest pairs a client-supplied RequestID with the request
+// to parse, so responses streamed back out of order can still be
+// correlated with what asked for them.
+type ParseUASTBatchRequest struct {
+	RequestID string
+	*protocol.ParseUASTRequest
+}
+
+// ParseUASTBatchResponse is a single response out of a batch, correlated to
+// its request via RequestID.
+type ParseUASTBatchResponse struct {
+	RequestID string
+	*protocol.ParseUASTResponse
+}
+
+// ParseUASTBatch is NOT reachable over gRPC: it is a local Go function, not
+// a registered service method. Exposing a streaming ParseUASTBatch RPC is
+// blocked on github.com/bblfsh/sdk/protocol defining the corresponding
+// generated stream types, which it does not today; this is the fan-out
+// engine that method would call into once that upstream work lands.
+//
+// Until then, this fans the requests read from in out across the
+// appropriate driver pools concurrently, bounded by MaxInFlightParses in
+// flight at a time, and writes each response to out as it completes. It
+// blocks until in is closed and every outstanding request has been
+// answered, then closes out.
+func (s *Server) ParseUASTBatch(in <-chan *ParseUASTBatchRequest, out chan<- *ParseUASTBatchResponse) {
+	defer close(out)
+
+	limit := s.MaxInFlightParses
+	if limit <= 0 {
+		limit = DefaultMaxInFlightParses
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+
+	for req := range in {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(req *ParseUASTBatchRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			out <- &ParseUASTBatchResponse{
+				RequestID:         req.RequestID,
+				ParseUASTResponse: s.ParseUAST(req.ParseUASTRequest),
+			}
+		}(req)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bblfsh/sdk/protocol"
+)
+
+func TestParseUASTBatchCorrelatesResponses(t *testing.T) {
+	s := &Server{
+		drivers: map[string]Driver{"python": &fakeDriver{}},
+		images:  map[string]string{"python": "docker:bblfsh/python-driver"},
+		Events:  NewEventBus(),
+	}
+
+	in := make(chan *ParseUASTBatchRequest, 2)
+	out := make(chan *ParseUASTBatchResponse, 2)
+
+	in <- &ParseUASTBatchRequest{RequestID: "a", ParseUASTRequest: &protocol.ParseUASTRequest{Language: "python"}}
+	in <- &ParseUASTBatchRequest{RequestID: "b", ParseUASTRequest: &protocol.ParseUASTRequest{Language: "python"}}
+	close(in)
+
+	s.ParseUASTBatch(in, out)
+
+	seen := map[string]bool{}
+	for resp := range out {
+		seen[resp.RequestID] = true
+	}
+
+	if len(seen) != 2 || !seen["a"] || !seen["b"] {
+		t.Fatalf("expected responses correlated to both request IDs, got %v", seen)
+	}
+}
+
+func TestParseUASTBatchRespectsMaxInFlightParses(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	track := func() bool {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return false
+	}
+
+	s := &Server{
+		drivers:           map[string]Driver{"python": &fakeDriver{fail: track}},
+		images:            map[string]string{"python": "docker:bblfsh/python-driver"},
+		Events:            NewEventBus(),
+		MaxInFlightParses: 1,
+	}
+
+	in := make(chan *ParseUASTBatchRequest, 3)
+	out := make(chan *ParseUASTBatchResponse, 3)
+
+	for i := 0; i < 3; i++ {
+		in <- &ParseUASTBatchRequest{RequestID: "req", ParseUASTRequest: &protocol.ParseUASTRequest{Language: "python"}}
+	}
+	close(in)
+
+	s.ParseUASTBatch(in, out)
+
+	count := 0
+	for range out {
+		count++
+	}
+
+	if count != 3 {
+		t.Fatalf("expected 3 responses, got %d", count)
+	}
+	if peak > 1 {
+		t.Fatalf("expected at most 1 request in flight, got %d", peak)
+	}
+}
@@ -0,0 +1,10 @@
+package server
+
+import "github.com/bblfsh/sdk/protocol"
+
+// Driver is a managed driver instance, capable of parsing UAST requests for
+// a single language.
+type Driver interface {
+	ParseUAST(*protocol.ParseUASTRequest) *protocol.ParseUASTResponse
+	Close() error
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"github.com/bblfsh/server/runtime"
+
+	"github.com/bblfsh/sdk/protocol"
+)
+
+// execDriver is a Driver backed by a single running driver container,
+// reached over the driver's own gRPC protocol.
+type execDriver struct {
+	rt    *runtime.Runtime
+	image *runtime.DriverImage
+	proc  *runtime.Process
+}
+
+// ExecDriver starts a new driver container for the given image using rt and
+// returns a Driver that proxies requests to it.
+func ExecDriver(rt *runtime.Runtime, image *runtime.DriverImage) (Driver, error) {
+	proc, err := rt.Start(image)
+	if err != nil {
+		return nil, ErrRuntime.Wrap(err)
+	}
+
+	return &execDriver{rt: rt, image: image, proc: proc}, nil
+}
+
+func (d *execDriver) ParseUAST(req *protocol.ParseUASTRequest) *protocol.ParseUASTResponse {
+	return d.proc.Client().ParseUAST(req)
+}
+
+func (d *execDriver) Close() error {
+	return d.proc.Stop()
+}
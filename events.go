@@ -0,0 +1,86 @@
+package server
+
+import "sync"
+
+// EventType identifies the kind of occurrence an Event describes.
+type EventType string
+
+const (
+	// EventDriverInstalled is emitted once a driver image has been
+	// installed and its pool started.
+	EventDriverInstalled EventType = "driver-installed"
+	// EventDriverEvicted is emitted when an unhealthy driver instance is
+	// evicted and replaced.
+	EventDriverEvicted EventType = "driver-evicted"
+	// EventPoolScaleUp is emitted when a driver pool starts a new
+	// instance to meet demand.
+	EventPoolScaleUp EventType = "pool-scale-up"
+	// EventPoolScaleDown is emitted when a driver pool stops an instance
+	// it no longer needs.
+	EventPoolScaleDown EventType = "pool-scale-down"
+	// EventParseStart is emitted when a ParseUAST request begins.
+	EventParseStart EventType = "parse-start"
+	// EventParseFinish is emitted when a ParseUAST request completes
+	// successfully.
+	EventParseFinish EventType = "parse-finish"
+	// EventParseError is emitted when a ParseUAST request fails.
+	EventParseError EventType = "parse-error"
+)
+
+// Event is a single occurrence published on a Server's EventBus.
+type Event struct {
+	Type     EventType
+	Language string
+	Image    string
+	Err      error
+}
+
+// EventBus is a publish/subscribe hub for Server events, modeled after the
+// subscribe/evict pattern used by Docker's daemon/events.
+type EventBus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// NewEventBus creates an empty EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[chan Event]struct{})}
+}
+
+// Subscribe registers a new listener and returns the channel it receives
+// events on. The channel is buffered; if a subscriber falls behind,
+// further events are dropped for it rather than blocking publishers.
+func (b *EventBus) Subscribe() chan Event {
+	ch := make(chan Event, 128)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch
+}
+
+// Evict unregisters a subscriber and closes its channel.
+func (b *EventBus) Evict(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[ch]; !ok {
+		return
+	}
+
+	delete(b.subs, ch)
+	close(ch)
+}
+
+func (b *EventBus) publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
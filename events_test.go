@@ -0,0 +1,41 @@
+package server
+
+import "testing"
+
+func TestEventBusPublishDeliversToSubscribers(t *testing.T) {
+	b := NewEventBus()
+	ch := b.Subscribe()
+
+	b.publish(Event{Type: EventDriverInstalled, Language: "python"})
+
+	ev := <-ch
+	if ev.Type != EventDriverInstalled || ev.Language != "python" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
+
+func TestEventBusPublishDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	b := NewEventBus()
+	ch := b.Subscribe()
+
+	for i := 0; i < cap(ch)+10; i++ {
+		b.publish(Event{Type: EventParseStart})
+	}
+}
+
+func TestEventBusEvictStopsDeliveryAndClosesChannel(t *testing.T) {
+	b := NewEventBus()
+	ch := b.Subscribe()
+
+	b.Evict(ch)
+	b.publish(Event{Type: EventParseFinish})
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected the channel to be closed after Evict")
+	}
+}
+
+func TestEventBusEvictUnknownChannelIsANoop(t *testing.T) {
+	b := NewEventBus()
+	b.Evict(make(chan Event))
+}
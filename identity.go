@@ -0,0 +1,154 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base32"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-errors.v0"
+)
+
+// ErrIdentity is returned when a server identity key cannot be loaded,
+// created, or parsed.
+var ErrIdentity = errors.NewKind("identity key failure")
+
+const identityPEMBlockType = "EC PRIVATE KEY"
+
+// IdentityKey is the server's own key pair, used in AuthIdentity mode to
+// let clients authorize the server, and the server authorize clients, by
+// public-key fingerprint rather than a certificate authority.
+type IdentityKey struct {
+	private *ecdsa.PrivateKey
+}
+
+// LoadOrCreateIdentity loads the identity key stored at path, generating
+// and persisting a new one if it doesn't exist yet.
+func LoadOrCreateIdentity(path string) (*IdentityKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		return parseIdentity(data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, ErrIdentity.Wrap(err)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, ErrIdentity.Wrap(err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, ErrIdentity.Wrap(err)
+	}
+
+	block := &pem.Block{Type: identityPEMBlockType, Bytes: der}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, ErrIdentity.Wrap(err)
+	}
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, ErrIdentity.Wrap(err)
+	}
+
+	return &IdentityKey{private: priv}, nil
+}
+
+func parseIdentity(data []byte) (*IdentityKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrIdentity.New()
+	}
+
+	priv, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrIdentity.Wrap(err)
+	}
+
+	return &IdentityKey{private: priv}, nil
+}
+
+// Fingerprint returns the public-key fingerprint clients pin to authorize
+// this server, e.g. "ABCD:EFGH:...".
+func (k *IdentityKey) Fingerprint() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&k.private.PublicKey)
+	if err != nil {
+		return "", ErrIdentity.Wrap(err)
+	}
+
+	return fingerprintPublicKey(der), nil
+}
+
+// fingerprintPublicKey formats the SHA-256 digest of a DER-encoded public
+// key as a colon-separated, base32 fingerprint, in the style popularized by
+// Docker's libtrust.
+func fingerprintPublicKey(der []byte) string {
+	sum := sha256.Sum256(der)
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:])
+
+	var b strings.Builder
+	for i := 0; i < len(enc); i += 4 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		end := i + 4
+		if end > len(enc) {
+			end = len(enc)
+		}
+		b.WriteString(enc[i:end])
+	}
+
+	return b.String()
+}
+
+// selfSignedCert wraps k in a minimal self-signed certificate so it can be
+// used as the server's TLS credential in AuthIdentity mode, where trust is
+// rooted in pinned fingerprints rather than a certificate authority.
+func (k *IdentityKey) selfSignedCert() (tls.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		return tls.Certificate{}, ErrIdentity.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "bblfsh-server identity"},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).AddDate(100, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &k.private.PublicKey, k.private)
+	if err != nil {
+		return tls.Certificate{}, ErrIdentity.Wrap(err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  k.private,
+	}, nil
+}
+
+// isTrusted reports whether fingerprint has a pinned entry in trustDir, one
+// file per authorized client named after its fingerprint with ':' replaced
+// by '-'.
+func isTrusted(trustDir, fingerprint string) bool {
+	if trustDir == "" {
+		return false
+	}
+
+	name := strings.Replace(fingerprint, ":", "-", -1)
+	_, err := os.Stat(filepath.Join(trustDir, name))
+	return err == nil
+}
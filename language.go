@@ -0,0 +1,32 @@
+package server
+
+import "path/filepath"
+
+// DefaultTransport is the transport used to fetch driver images when none
+// is specified.
+const DefaultTransport = "docker"
+
+// DefaultDriverImageReference returns the default image reference for a
+// driver of the given language, using transport to fetch it.
+func DefaultDriverImageReference(transport, lang string) string {
+	if transport == "" {
+		transport = DefaultTransport
+	}
+
+	return transport + ":bblfsh/" + lang + "-driver:latest"
+}
+
+// GetLanguage guesses the language of a file from its filename extension;
+// content is not inspected.
+func GetLanguage(filename string, content []byte) string {
+	switch filepath.Ext(filename) {
+	case ".py":
+		return "python"
+	case ".go":
+		return "go"
+	case ".java":
+		return "java"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/bblfsh/server/runtime"
+
+	"gopkg.in/src-d/go-errors.v0"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ErrManifest is returned when a driver manifest cannot be read or is
+// malformed.
+var ErrManifest = errors.NewKind("invalid driver manifest")
+
+// DriverManifestEntry describes one driver to provision, as listed in a
+// manifest passed to InstallDriversFromManifest.
+type DriverManifestEntry struct {
+	Language string `json:"language" yaml:"language"`
+	Image    string `json:"image" yaml:"image"`
+	// Transport overrides Server.Transport for this entry only. It may
+	// also be given inline as a scheme prefix on Image.
+	Transport string `json:"transport,omitempty" yaml:"transport,omitempty"`
+	// PinnedDigest, when set, is appended to Image as "@<digest>" so the
+	// exact content installed is reproducible.
+	PinnedDigest string `json:"pinned_digest,omitempty" yaml:"pinned_digest,omitempty"`
+}
+
+func (e DriverManifestEntry) imageReference() string {
+	img := e.Image
+	if e.PinnedDigest != "" {
+		img += "@" + e.PinnedDigest
+	}
+
+	if e.Transport != "" {
+		img = e.Transport + ":" + img
+	}
+
+	return img
+}
+
+// InstallDriversFromManifest reads a YAML or JSON manifest of
+// DriverManifestEntry from r and installs each driver in parallel. An entry
+// that is already installed is skipped rather than treated as an error, so
+// manifests can be reapplied idempotently.
+//
+// This, RemoveDriver and UpdateDriver are local Go APIs only: exposing them
+// as a ManagementService gRPC method is blocked on github.com/bblfsh/sdk/
+// protocol defining the corresponding request/response types.
+func (s *Server) InstallDriversFromManifest(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return ErrManifest.Wrap(err)
+	}
+
+	var entries []DriverManifestEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return ErrManifest.Wrap(err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for _, e := range entries {
+		wg.Add(1)
+		go func(e DriverManifestEntry) {
+			defer wg.Done()
+
+			err := s.AddDriver(e.Language, e.imageReference())
+			if err != nil && !ErrAlreadyInstalled.Is(err) {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(e)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return ErrManifest.Wrap(firstErr)
+	}
+
+	return nil
+}
+
+// RemoveDriver uninstalls the driver pool for lang, closing its instances.
+func (s *Server) RemoveDriver(lang string) error {
+	s.mu.Lock()
+	d, ok := s.drivers[lang]
+	if !ok {
+		s.mu.Unlock()
+		return ErrMissingDriver.New(lang)
+	}
+
+	delete(s.drivers, lang)
+	delete(s.images, lang)
+	s.mu.Unlock()
+
+	return d.Close()
+}
+
+// UpdateDriver replaces the driver installed for lang with newImg, starting
+// the new pool before draining and closing the old one so in-flight
+// requests keep being served during the swap.
+func (s *Server) UpdateDriver(lang string, newImg string) error {
+	image, err := runtime.ResolveDriverImage(s.Transport, newImg)
+	if err != nil {
+		return ErrRuntime.Wrap(err)
+	}
+
+	if err := s.rt.InstallDriver(image, true); err != nil {
+		return ErrRuntime.Wrap(err)
+	}
+
+	dp, err := StartDriverPool(lang, DefaultScalingPolicy(), DefaultPoolTimeout, func() (Driver, error) {
+		return ExecDriver(s.rt, image)
+	}, s.Events)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	old, hadOld := s.drivers[lang]
+	s.drivers[lang] = dp
+	s.images[lang] = newImg
+	s.mu.Unlock()
+
+	s.Events.publish(Event{Type: EventDriverInstalled, Language: lang, Image: newImg})
+
+	if !hadOld {
+		return nil
+	}
+
+	return old.Close()
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bblfsh/server/runtime"
+)
+
+func newTestServer() *Server {
+	s := NewServer(runtime.NewRuntime("/tmp/bblfsh-manifest-test"))
+	s.Transport = "docker"
+	return s
+}
+
+func TestInstallDriversFromManifestInstallsEveryEntry(t *testing.T) {
+	s := newTestServer()
+
+	manifest := strings.NewReader(`
+- language: python
+  image: bblfsh/python-driver:latest
+- language: go
+  image: bblfsh/go-driver:latest
+`)
+
+	if err := s.InstallDriversFromManifest(manifest); err != nil {
+		t.Fatalf("InstallDriversFromManifest: %s", err)
+	}
+
+	for _, lang := range []string{"python", "go"} {
+		if _, ok := s.drivers[lang]; !ok {
+			t.Fatalf("expected a driver pool installed for %q", lang)
+		}
+	}
+}
+
+func TestInstallDriversFromManifestSkipsAlreadyInstalled(t *testing.T) {
+	s := newTestServer()
+
+	manifest := func() *strings.Reader {
+		return strings.NewReader("- language: python\n  image: bblfsh/python-driver:latest\n")
+	}
+
+	if err := s.InstallDriversFromManifest(manifest()); err != nil {
+		t.Fatalf("first InstallDriversFromManifest: %s", err)
+	}
+	if err := s.InstallDriversFromManifest(manifest()); err != nil {
+		t.Fatalf("reapplying the manifest should be idempotent: %s", err)
+	}
+}
+
+func TestInstallDriversFromManifestRejectsMalformedYAML(t *testing.T) {
+	s := newTestServer()
+
+	if err := s.InstallDriversFromManifest(strings.NewReader("not: [valid")); err == nil {
+		t.Fatal("expected an error for malformed manifest data")
+	}
+}
+
+func TestRemoveDriverUninstallsAndClosesThePool(t *testing.T) {
+	s := newTestServer()
+	if err := s.AddDriver("python", "bblfsh/python-driver:latest"); err != nil {
+		t.Fatalf("AddDriver: %s", err)
+	}
+	pool := s.drivers["python"].(*DriverPool)
+
+	if err := s.RemoveDriver("python"); err != nil {
+		t.Fatalf("RemoveDriver: %s", err)
+	}
+
+	if !pool.closed {
+		t.Fatal("expected the removed driver pool to be closed")
+	}
+	if _, ok := s.drivers["python"]; ok {
+		t.Fatal("expected the driver to be removed")
+	}
+	if _, ok := s.images["python"]; ok {
+		t.Fatal("expected the image reference to be removed")
+	}
+}
+
+func TestRemoveDriverMissingLanguage(t *testing.T) {
+	s := newTestServer()
+	if err := s.RemoveDriver("ruby"); !ErrMissingDriver.Is(err) {
+		t.Fatalf("expected ErrMissingDriver, got %v", err)
+	}
+}
+
+func TestUpdateDriverSwapsInstallingTheNewPoolFirst(t *testing.T) {
+	s := newTestServer()
+	if err := s.AddDriver("python", "bblfsh/python-driver:1.0"); err != nil {
+		t.Fatalf("AddDriver: %s", err)
+	}
+	oldPool := s.drivers["python"]
+
+	if err := s.UpdateDriver("python", "bblfsh/python-driver:2.0"); err != nil {
+		t.Fatalf("UpdateDriver: %s", err)
+	}
+
+	if s.images["python"] != "bblfsh/python-driver:2.0" {
+		t.Fatalf("expected the image reference to be updated, got %q", s.images["python"])
+	}
+	if s.drivers["python"] == oldPool {
+		t.Fatal("expected a new driver pool to replace the old one")
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	parsesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "bblfsh",
+		Subsystem: "server",
+		Name:      "parses_total",
+		Help:      "Total number of ParseUAST requests, by language and status.",
+	}, []string{"language", "status"})
+
+	parseLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "bblfsh",
+		Subsystem: "server",
+		Name:      "parse_latency_seconds",
+		Help:      "Time spent parsing a request, by language.",
+	}, []string{"language"})
+
+	queueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "bblfsh",
+		Subsystem: "server",
+		Name:      "queue_wait_seconds",
+		Help:      "Time a request waited for a free driver instance, by language.",
+	}, []string{"language"})
+
+	poolSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bblfsh",
+		Subsystem: "server",
+		Name:      "pool_size",
+		Help:      "Number of driver instances currently running, by language.",
+	}, []string{"language"})
+
+	inFlightRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "bblfsh",
+		Subsystem: "server",
+		Name:      "in_flight_requests",
+		Help:      "Number of ParseUAST requests currently being served, by language.",
+	}, []string{"language"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		parsesTotal,
+		parseLatency,
+		queueWait,
+		poolSize,
+		inFlightRequests,
+	)
+}
+
+// MetricsHandler returns an http.Handler exposing the process' Prometheus
+// metrics, suitable for mounting at "/metrics".
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/bblfsh/sdk/protocol"
+	"gopkg.in/src-d/go-errors.v0"
+)
+
+var ErrPoolClosed = errors.NewKind("driver pool closed")
+
+// DefaultPoolTimeout is the default time a DriverPool gives its in-flight
+// requests to finish when it is closed, before closing instances out from
+// under them.
+const DefaultPoolTimeout = 5 * time.Second
+
+// DefaultHealthCheckInterval is the default period between health probes
+// of a pooled driver instance.
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// DefaultHealthCheckTimeout is the default time a health probe waits for a
+// driver to respond before it is counted as a failure.
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// DefaultMaxConsecutiveFailures is the default number of consecutive failed
+// health checks after which a driver instance is evicted.
+const DefaultMaxConsecutiveFailures = 3
+
+// ScalingPolicy decides how many driver instances a DriverPool should run,
+// given the current load and the number of instances already running.
+type ScalingPolicy interface {
+	Scale(load int, current int) int
+}
+
+type fixedScalingPolicy struct {
+	size int
+}
+
+// DefaultScalingPolicy returns a ScalingPolicy that keeps a single driver
+// instance running at all times.
+func DefaultScalingPolicy() ScalingPolicy {
+	return &fixedScalingPolicy{size: 1}
+}
+
+func (p *fixedScalingPolicy) Scale(load, current int) int {
+	return p.size
+}
+
+// DriverFactory creates a new Driver instance, e.g. by starting a new
+// driver container.
+type DriverFactory func() (Driver, error)
+
+// DriverHealth is the observed health of a pooled driver instance.
+type DriverHealth struct {
+	// Healthy is false once the driver has reached MaxConsecutiveFailures.
+	Healthy bool
+	// ConsecutiveFailures is the number of health checks failed in a row.
+	ConsecutiveFailures int
+	// LastError is the error returned by the last failed health check,
+	// if any.
+	LastError error
+	// LastCheck is when the last health check ran.
+	LastCheck time.Time
+}
+
+type pooledDriver struct {
+	Driver
+	health DriverHealth
+	// sem gates concurrent access to this instance: a driver container
+	// handles one request at a time, so a second caller routed to it by
+	// round robin blocks here until the first is done.
+	sem chan struct{}
+}
+
+// DriverPool manages a pool of Driver instances for a single language,
+// scaling it according to a ScalingPolicy and periodically health-checking
+// its instances, replacing any that become unhealthy.
+type DriverPool struct {
+	// HealthCheckInterval is how often instances are probed. Zero
+	// disables health checking. It is read once, when the health checker
+	// starts, and StartDriverPool starts it with DefaultHealthCheckInterval.
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout bounds how long a single probe may take.
+	HealthCheckTimeout time.Duration
+	// MaxConsecutiveFailures is the number of consecutive failed probes
+	// after which an instance is evicted and replaced.
+	MaxConsecutiveFailures int
+	// Events, when set, receives pool scaling and driver eviction events.
+	Events *EventBus
+
+	language string
+	policy   ScalingPolicy
+	timeout  time.Duration
+	factory  DriverFactory
+
+	mu       sync.Mutex
+	drivers  []*pooledDriver
+	next     uint64
+	inFlight int64
+	closed   bool
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// StartDriverPool creates a DriverPool for language, scaled according to
+// policy, using factory to create new driver instances, and starts its
+// health checker with the default settings. events, if non-nil, is wired in
+// before the health checker starts so it never races with a concurrent
+// Eviction or scaling event.
+func StartDriverPool(language string, policy ScalingPolicy, timeout time.Duration, factory DriverFactory, events *EventBus) (*DriverPool, error) {
+	p := &DriverPool{
+		HealthCheckInterval:    DefaultHealthCheckInterval,
+		HealthCheckTimeout:     DefaultHealthCheckTimeout,
+		MaxConsecutiveFailures: DefaultMaxConsecutiveFailures,
+		Events:                 events,
+		language:               language,
+		policy:                 policy,
+		timeout:                timeout,
+		factory:                factory,
+		stop:                   make(chan struct{}),
+	}
+
+	d, err := p.newDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	p.drivers = append(p.drivers, d)
+	poolSize.WithLabelValues(p.language).Set(1)
+	p.wg.Add(1)
+	go p.healthLoop()
+
+	return p, nil
+}
+
+func (p *DriverPool) publish(t EventType) {
+	if p.Events == nil {
+		return
+	}
+
+	p.Events.publish(Event{Type: t, Language: p.language})
+}
+
+func (p *DriverPool) newDriver() (*pooledDriver, error) {
+	d, err := p.factory()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pooledDriver{Driver: d, health: DriverHealth{Healthy: true}, sem: make(chan struct{}, 1)}, nil
+}
+
+func (p *DriverPool) healthLoop() {
+	defer p.wg.Done()
+
+	if p.HealthCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkAll()
+			p.rescale()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// rescale asks p.policy how many instances the pool should run given its
+// current load, and starts or stops instances to match, publishing an
+// EventPoolScaleUp/EventPoolScaleDown per instance added or removed.
+func (p *DriverPool) rescale() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	load := int(atomic.LoadInt64(&p.inFlight))
+	target := p.policy.Scale(load, len(p.drivers))
+
+	for len(p.drivers) < target {
+		d, err := p.newDriver()
+		if err != nil {
+			logrus.Errorf("unable to scale up driver pool: %s", err)
+			break
+		}
+
+		p.drivers = append(p.drivers, d)
+		poolSize.WithLabelValues(p.language).Set(float64(len(p.drivers)))
+		p.publish(EventPoolScaleUp)
+	}
+
+	for len(p.drivers) > target && len(p.drivers) > 1 {
+		idx := len(p.drivers) - 1
+		old := p.drivers[idx]
+		p.drivers = p.drivers[:idx]
+
+		old.Driver.Close()
+		poolSize.WithLabelValues(p.language).Set(float64(len(p.drivers)))
+		p.publish(EventPoolScaleDown)
+	}
+}
+
+func (p *DriverPool) checkAll() {
+	p.mu.Lock()
+	drivers := make([]*pooledDriver, len(p.drivers))
+	copy(drivers, p.drivers)
+	p.mu.Unlock()
+
+	for i, d := range drivers {
+		p.check(i, d)
+	}
+}
+
+// check runs a single health probe against d, a lightweight ParseUAST ping,
+// and evicts and replaces it once it has reached MaxConsecutiveFailures.
+func (p *DriverPool) check(idx int, d *pooledDriver) {
+	done := make(chan *protocol.ParseUASTResponse, 1)
+	go func() {
+		done <- d.ParseUAST(&protocol.ParseUASTRequest{})
+	}()
+
+	var err error
+	select {
+	case resp := <-done:
+		if resp == nil || resp.Status == protocol.Fatal {
+			err = ErrRuntime.New()
+		}
+	case <-time.After(p.HealthCheckTimeout):
+		err = ErrRuntime.New()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d.health.LastCheck = time.Now()
+	if err == nil {
+		d.health.ConsecutiveFailures = 0
+		d.health.LastError = nil
+		d.health.Healthy = true
+		return
+	}
+
+	d.health.ConsecutiveFailures++
+	d.health.LastError = err
+	if d.health.ConsecutiveFailures < p.MaxConsecutiveFailures {
+		return
+	}
+
+	d.health.Healthy = false
+	logrus.Warnf("evicting unhealthy driver after %d consecutive failures", d.health.ConsecutiveFailures)
+	p.evictLocked(idx, d)
+	p.publish(EventDriverEvicted)
+}
+
+// evictLocked replaces the driver at idx with a freshly started one. p.mu
+// must be held by the caller.
+func (p *DriverPool) evictLocked(idx int, old *pooledDriver) {
+	old.Driver.Close()
+
+	replacement, err := p.newDriver()
+	if err != nil {
+		logrus.Errorf("unable to replace unhealthy driver: %s", err)
+		return
+	}
+
+	if idx < len(p.drivers) {
+		p.drivers[idx] = replacement
+	}
+}
+
+// Status returns a snapshot of the health of every instance in the pool.
+func (p *DriverPool) Status() []DriverHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := make([]DriverHealth, len(p.drivers))
+	for i, d := range p.drivers {
+		status[i] = d.health
+	}
+
+	return status
+}
+
+func (p *DriverPool) ParseUAST(req *protocol.ParseUASTRequest) *protocol.ParseUASTResponse {
+	p.mu.Lock()
+	idx := int(atomic.AddUint64(&p.next, 1)-1) % len(p.drivers)
+	d := p.drivers[idx]
+	p.mu.Unlock()
+
+	// A driver container serves one request at a time. If round robin
+	// routed us to an instance that's still busy with a previous request,
+	// we genuinely queue behind it here, and queueWait reports that time.
+	waitStart := time.Now()
+	d.sem <- struct{}{}
+	queueWait.WithLabelValues(p.language).Observe(time.Since(waitStart).Seconds())
+	defer func() { <-d.sem }()
+
+	atomic.AddInt64(&p.inFlight, 1)
+	inFlightRequests.WithLabelValues(p.language).Inc()
+	defer func() {
+		atomic.AddInt64(&p.inFlight, -1)
+		inFlightRequests.WithLabelValues(p.language).Dec()
+	}()
+
+	return d.ParseUAST(req)
+}
+
+// drain waits for in-flight requests to finish, up to p.timeout, before
+// Close proceeds to stop driver instances out from under them.
+func (p *DriverPool) drain() {
+	deadline := time.Now().Add(p.timeout)
+	for atomic.LoadInt64(&p.inFlight) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (p *DriverPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.stop)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	p.drain()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for _, d := range p.drivers {
+		if cerr := d.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	poolSize.DeleteLabelValues(p.language)
+	inFlightRequests.DeleteLabelValues(p.language)
+
+	return err
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bblfsh/sdk/protocol"
+)
+
+type fakeDriver struct {
+	closed bool
+	fail   func() bool
+}
+
+func (d *fakeDriver) ParseUAST(req *protocol.ParseUASTRequest) *protocol.ParseUASTResponse {
+	if d.fail != nil && d.fail() {
+		return &protocol.ParseUASTResponse{Status: protocol.Fatal}
+	}
+
+	return &protocol.ParseUASTResponse{}
+}
+
+func (d *fakeDriver) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestDriverPoolEvictsUnhealthyDriver(t *testing.T) {
+	failing := true
+	factory := func() (Driver, error) {
+		return &fakeDriver{fail: func() bool { return failing }}, nil
+	}
+
+	dp, err := StartDriverPool("test", DefaultScalingPolicy(), DefaultPoolTimeout, factory, nil)
+	if err != nil {
+		t.Fatalf("StartDriverPool: %s", err)
+	}
+	dp.HealthCheckInterval = 0 // drive checks manually below
+	dp.MaxConsecutiveFailures = 2
+	defer dp.Close()
+
+	first := dp.drivers[0]
+	dp.check(0, first)
+	dp.check(0, first)
+
+	if !first.Driver.(*fakeDriver).closed {
+		t.Fatal("expected the unhealthy driver to be closed on eviction")
+	}
+
+	status := dp.Status()
+	if len(status) != 1 {
+		t.Fatalf("expected 1 driver in the pool, got %d", len(status))
+	}
+	if !status[0].Healthy {
+		t.Fatal("expected the replacement driver to report healthy")
+	}
+
+	failing = false
+}
+
+func TestDriverPoolStatusReflectsHealthyInstance(t *testing.T) {
+	factory := func() (Driver, error) {
+		return &fakeDriver{}, nil
+	}
+
+	dp, err := StartDriverPool("test", DefaultScalingPolicy(), DefaultPoolTimeout, factory, nil)
+	if err != nil {
+		t.Fatalf("StartDriverPool: %s", err)
+	}
+	dp.HealthCheckInterval = 0
+	defer dp.Close()
+
+	dp.check(0, dp.drivers[0])
+
+	status := dp.Status()
+	if len(status) != 1 {
+		t.Fatalf("expected 1 driver in the pool, got %d", len(status))
+	}
+	if !status[0].Healthy || status[0].ConsecutiveFailures != 0 {
+		t.Fatalf("expected a clean bill of health, got %+v", status[0])
+	}
+}
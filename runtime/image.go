@@ -0,0 +1,20 @@
+package runtime
+
+// DriverImage is a reference to a driver image, resolved to a concrete
+// location the Runtime knows how to fetch and run.
+type DriverImage struct {
+	// Language is the language the driver handles.
+	Language string
+	// Reference is the original image reference, e.g.
+	// "bblfsh/python-driver:latest".
+	Reference string
+}
+
+// NewDriverImage resolves an image reference into a DriverImage.
+func NewDriverImage(reference string) (*DriverImage, error) {
+	return &DriverImage{Reference: reference}, nil
+}
+
+func (i *DriverImage) String() string {
+	return i.Reference
+}
@@ -0,0 +1,98 @@
+package runtime
+
+import (
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v0"
+)
+
+// ErrUnknownTransport is returned when an image reference uses a scheme with
+// no registered DriverImageResolver.
+var ErrUnknownTransport = errors.NewKind("unknown driver image transport: %s")
+
+// DriverImageResolver resolves a scheme-less image reference into a
+// DriverImage ready to be installed by a Runtime.
+type DriverImageResolver interface {
+	Resolve(ref string) (*DriverImage, error)
+}
+
+var resolvers = map[string]DriverImageResolver{}
+
+// RegisterDriverImageResolver registers r as the resolver used for image
+// references prefixed with "scheme:", e.g. "docker-daemon:".
+func RegisterDriverImageResolver(scheme string, r DriverImageResolver) {
+	resolvers[scheme] = r
+}
+
+func init() {
+	RegisterDriverImageResolver("docker", dockerResolver{})
+	RegisterDriverImageResolver("docker-daemon", dockerDaemonResolver{})
+	RegisterDriverImageResolver("oci-layout", ociLayoutResolver{})
+	RegisterDriverImageResolver("file", fileResolver{})
+}
+
+// ResolveDriverImage resolves ref using the resolver matching its scheme
+// prefix, e.g. "docker-daemon:bblfsh/python-driver:latest". References
+// without a recognized scheme fall back to defaultTransport.
+func ResolveDriverImage(defaultTransport, ref string) (*DriverImage, error) {
+	scheme, rest := splitScheme(ref)
+	if scheme == "" {
+		scheme, rest = defaultTransport, ref
+	}
+
+	r, ok := resolvers[scheme]
+	if !ok {
+		return nil, ErrUnknownTransport.New(scheme)
+	}
+
+	return r.Resolve(rest)
+}
+
+// splitScheme splits ref into a transport scheme and the remaining
+// reference. The text before the first colon is only treated as a scheme
+// if it names a registered DriverImageResolver, so "docker-daemon:bblfsh/
+// python-driver" splits but a plain reference against a registry with a
+// port, e.g. "myregistry.local:5000/bblfsh/python-driver:latest", is left
+// untouched since "myregistry.local" isn't a registered scheme.
+func splitScheme(ref string) (scheme, rest string) {
+	idx := strings.Index(ref, ":")
+	if idx < 0 {
+		return "", ref
+	}
+
+	candidate := ref[:idx]
+	if _, ok := resolvers[candidate]; !ok {
+		return "", ref
+	}
+
+	return candidate, ref[idx+1:]
+}
+
+type dockerResolver struct{}
+
+func (dockerResolver) Resolve(ref string) (*DriverImage, error) {
+	return NewDriverImage(ref)
+}
+
+// dockerDaemonResolver resolves images already present in a local Docker
+// daemon, without a registry roundtrip.
+type dockerDaemonResolver struct{}
+
+func (dockerDaemonResolver) Resolve(ref string) (*DriverImage, error) {
+	return &DriverImage{Reference: ref}, nil
+}
+
+// ociLayoutResolver resolves images from an on-disk OCI image layout
+// directory.
+type ociLayoutResolver struct{}
+
+func (ociLayoutResolver) Resolve(ref string) (*DriverImage, error) {
+	return &DriverImage{Reference: ref}, nil
+}
+
+// fileResolver resolves images from a raw image tarball on disk.
+type fileResolver struct{}
+
+func (fileResolver) Resolve(ref string) (*DriverImage, error) {
+	return &DriverImage{Reference: ref}, nil
+}
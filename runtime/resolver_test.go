@@ -0,0 +1,42 @@
+package runtime
+
+import "testing"
+
+func TestSplitScheme(t *testing.T) {
+	cases := []struct {
+		ref        string
+		wantScheme string
+		wantRest   string
+	}{
+		{"docker-daemon:bblfsh/python-driver:latest", "docker-daemon", "bblfsh/python-driver:latest"},
+		{"bblfsh/python-driver:latest", "", "bblfsh/python-driver:latest"},
+		{"myregistry.local:5000/bblfsh/python-driver:latest", "", "myregistry.local:5000/bblfsh/python-driver:latest"},
+		{"file:/tmp/python-driver.tar", "file", "/tmp/python-driver.tar"},
+		{"bblfsh/python-driver", "", "bblfsh/python-driver"},
+	}
+
+	for _, c := range cases {
+		scheme, rest := splitScheme(c.ref)
+		if scheme != c.wantScheme || rest != c.wantRest {
+			t.Errorf("splitScheme(%q) = (%q, %q), want (%q, %q)",
+				c.ref, scheme, rest, c.wantScheme, c.wantRest)
+		}
+	}
+}
+
+func TestResolveDriverImageFallsBackToDefaultTransport(t *testing.T) {
+	image, err := ResolveDriverImage("docker-daemon", "myregistry.local:5000/bblfsh/python-driver:latest")
+	if err != nil {
+		t.Fatalf("ResolveDriverImage: %s", err)
+	}
+	if image.Reference != "myregistry.local:5000/bblfsh/python-driver:latest" {
+		t.Fatalf("unexpected reference: %q", image.Reference)
+	}
+}
+
+func TestResolveDriverImageUnknownTransport(t *testing.T) {
+	_, err := ResolveDriverImage("bogus-transport", "bblfsh/python-driver")
+	if err == nil {
+		t.Fatal("expected an error for an unregistered default transport")
+	}
+}
